@@ -0,0 +1,257 @@
+package shared
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+	quotaapi "github.com/openshift/origin/pkg/quota/api"
+)
+
+// clusterResourceQuotaCacheLastRefresh and imageStreamCacheLastRefresh are registered once at
+// package scope, rather than per cache instance, so that constructing more than one
+// CachedClusterResourceQuotaLister or CachedImageStreamLister in the same process (e.g. two
+// admission plugins, or two tests in the same binary) doesn't panic on duplicate registration.
+var (
+	clusterResourceQuotaCacheLastRefresh = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cluster_resource_quota_cache_last_refresh_timestamp",
+		Help: "Unix timestamp of the last successful background refresh of the cluster resource quota cache",
+	})
+	imageStreamCacheLastRefresh = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "image_stream_cache_last_refresh_timestamp",
+		Help: "Unix timestamp of the last successful background refresh of the image stream cache",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(clusterResourceQuotaCacheLastRefresh)
+	prometheus.MustRegister(imageStreamCacheLastRefresh)
+}
+
+// refresher runs refreshFunc on a background goroutine every ttl, or immediately when Refresh is
+// called, coalescing any refresh requests that arrive while one is already pending. It records
+// the unix time of the last successful refresh in metric, which the caller must have already
+// registered.
+type refresher struct {
+	name        string
+	ttl         time.Duration
+	refreshFunc func() error
+	refreshCh   chan struct{}
+	metric      prometheus.Gauge
+}
+
+func newRefresher(name string, metric prometheus.Gauge, ttl time.Duration, refreshFunc func() error) *refresher {
+	return &refresher{
+		name:        name,
+		ttl:         ttl,
+		refreshFunc: refreshFunc,
+		refreshCh:   make(chan struct{}, 1),
+		metric:      metric,
+	}
+}
+
+// Refresh requests an out-of-band refresh. It returns immediately; the refresh happens on the
+// background goroutine started by run. A request made while another is still pending is absorbed
+// by it; a request made while a refresh is running queues one more refresh after that one.
+func (r *refresher) Refresh() {
+	select {
+	case r.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *refresher) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(r.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.doRefresh()
+		case <-r.refreshCh:
+			r.doRefresh()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (r *refresher) doRefresh() {
+	if err := r.refreshFunc(); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to refresh %s cache: %v", r.name, err))
+		return
+	}
+	r.metric.Set(float64(time.Now().Unix()))
+}
+
+// ClusterResourceQuotaLister is the read interface exposed by clusterResourceQuotaInformer.Lister().
+type ClusterResourceQuotaLister interface {
+	List(selector labels.Selector) ([]*quotaapi.ClusterResourceQuota, error)
+	Get(name string) (*quotaapi.ClusterResourceQuota, error)
+}
+
+// CachedClusterResourceQuotaLister wraps a ClusterResourceQuotaLister and memoises its results for
+// ttl, refreshing them asynchronously so that hot admission paths never pay the cost of walking the
+// underlying informer's index directly. List and Get may serve data that is stale by up to ttl;
+// call Refresh to force an out-of-band update (e.g. after a quota is known to have changed).
+type CachedClusterResourceQuotaLister struct {
+	lister ClusterResourceQuotaLister
+	*refresher
+
+	lock   sync.RWMutex
+	quotas []*quotaapi.ClusterResourceQuota
+	byName map[string]*quotaapi.ClusterResourceQuota
+}
+
+// NewCachedClusterResourceQuotaLister creates a CachedClusterResourceQuotaLister backed by lister,
+// populates it synchronously so the first caller never sees an empty cache, and starts its
+// background refresh loop. The loop exits when stopCh is closed.
+func NewCachedClusterResourceQuotaLister(lister ClusterResourceQuotaLister, ttl time.Duration, stopCh <-chan struct{}) (*CachedClusterResourceQuotaLister, error) {
+	c := &CachedClusterResourceQuotaLister{lister: lister}
+	c.refresher = newRefresher("cluster resource quota", clusterResourceQuotaCacheLastRefresh, ttl, c.refreshCache)
+
+	if err := c.refreshCache(); err != nil {
+		return nil, err
+	}
+	go c.run(stopCh)
+
+	return c, nil
+}
+
+func (c *CachedClusterResourceQuotaLister) refreshCache() error {
+	quotas, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*quotaapi.ClusterResourceQuota, len(quotas))
+	for _, quota := range quotas {
+		byName[quota.Name] = quota
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.quotas = quotas
+	c.byName = byName
+	return nil
+}
+
+func (c *CachedClusterResourceQuotaLister) List(selector labels.Selector) ([]*quotaapi.ClusterResourceQuota, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if selector.Empty() {
+		return c.quotas, nil
+	}
+	ret := make([]*quotaapi.ClusterResourceQuota, 0, len(c.quotas))
+	for _, quota := range c.quotas {
+		if selector.Matches(labels.Set(quota.Labels)) {
+			ret = append(ret, quota)
+		}
+	}
+	return ret, nil
+}
+
+func (c *CachedClusterResourceQuotaLister) Get(name string) (*quotaapi.ClusterResourceQuota, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	quota, ok := c.byName[name]
+	if !ok {
+		return nil, kapierrors.NewNotFound(quotaapi.Resource("clusterresourcequota"), name)
+	}
+	return quota, nil
+}
+
+// ImageStreamLister is the read interface exposed by imageStreamInformer.Lister().
+type ImageStreamLister interface {
+	List(namespace string, selector labels.Selector) ([]*imageapi.ImageStream, error)
+	Get(namespace, name string) (*imageapi.ImageStream, error)
+}
+
+// CachedImageStreamLister is the ImageStreamLister analogue of CachedClusterResourceQuotaLister,
+// for admission plugins (e.g. image limit range, image policy) that otherwise hit the image stream
+// informer's index on every request.
+type CachedImageStreamLister struct {
+	lister ImageStreamLister
+	*refresher
+
+	lock    sync.RWMutex
+	streams map[string][]*imageapi.ImageStream
+}
+
+// NewCachedImageStreamLister creates a CachedImageStreamLister backed by lister, populates it
+// synchronously, and starts its background refresh loop. The loop exits when stopCh is closed.
+func NewCachedImageStreamLister(lister ImageStreamLister, ttl time.Duration, stopCh <-chan struct{}) (*CachedImageStreamLister, error) {
+	c := &CachedImageStreamLister{lister: lister}
+	c.refresher = newRefresher("image stream", imageStreamCacheLastRefresh, ttl, c.refreshCache)
+
+	if err := c.refreshCache(); err != nil {
+		return nil, err
+	}
+	go c.run(stopCh)
+
+	return c, nil
+}
+
+func (c *CachedImageStreamLister) refreshCache() error {
+	streams, err := c.lister.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	byNamespace := map[string][]*imageapi.ImageStream{}
+	for _, stream := range streams {
+		byNamespace[stream.Namespace] = append(byNamespace[stream.Namespace], stream)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.streams = byNamespace
+	return nil
+}
+
+func (c *CachedImageStreamLister) List(namespace string, selector labels.Selector) ([]*imageapi.ImageStream, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var candidates []*imageapi.ImageStream
+	if namespace == metav1.NamespaceAll {
+		for _, streams := range c.streams {
+			candidates = append(candidates, streams...)
+		}
+	} else {
+		candidates = c.streams[namespace]
+	}
+
+	if selector.Empty() {
+		return candidates, nil
+	}
+	ret := make([]*imageapi.ImageStream, 0, len(candidates))
+	for _, stream := range candidates {
+		if selector.Matches(labels.Set(stream.Labels)) {
+			ret = append(ret, stream)
+		}
+	}
+	return ret, nil
+}
+
+func (c *CachedImageStreamLister) Get(namespace, name string) (*imageapi.ImageStream, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for _, stream := range c.streams[namespace] {
+		if stream.Name == name {
+			return stream, nil
+		}
+	}
+	return nil, kapierrors.NewNotFound(imageapi.Resource("imagestream"), name)
+}
@@ -0,0 +1,208 @@
+package shared
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+	quotaapi "github.com/openshift/origin/pkg/quota/api"
+)
+
+// fakeClusterResourceQuotaLister is a ClusterResourceQuotaLister whose backing quotas can be
+// swapped out at runtime, to drive CachedClusterResourceQuotaLister's refresh behavior.
+type fakeClusterResourceQuotaLister struct {
+	lock   sync.Mutex
+	quotas []*quotaapi.ClusterResourceQuota
+}
+
+func (f *fakeClusterResourceQuotaLister) setQuotas(quotas []*quotaapi.ClusterResourceQuota) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.quotas = quotas
+}
+
+func (f *fakeClusterResourceQuotaLister) List(selector labels.Selector) ([]*quotaapi.ClusterResourceQuota, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*quotaapi.ClusterResourceQuota{}, f.quotas...), nil
+}
+
+func (f *fakeClusterResourceQuotaLister) Get(name string) (*quotaapi.ClusterResourceQuota, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, quota := range f.quotas {
+		if quota.Name == name {
+			return quota, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestCachedClusterResourceQuotaLister(t *testing.T) {
+	fake := &fakeClusterResourceQuotaLister{
+		quotas: []*quotaapi.ClusterResourceQuota{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	cached, err := NewCachedClusterResourceQuotaLister(fake, time.Hour, stopCh)
+	if err != nil {
+		t.Fatalf("unexpected error constructing cache: %v", err)
+	}
+
+	quotas, err := cached.List(labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error listing: %v", err)
+	}
+	if len(quotas) != 1 || quotas[0].Name != "a" {
+		t.Fatalf("expected the synchronously-populated quota %q, got %+v", "a", quotas)
+	}
+
+	if _, err := cached.Get("missing"); err == nil {
+		t.Errorf("expected a not-found error for a quota that doesn't exist")
+	}
+
+	// The cache has a one-hour TTL, so List must keep serving the stale result until Refresh is
+	// called explicitly.
+	fake.setQuotas([]*quotaapi.ClusterResourceQuota{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+	})
+	quotas, err = cached.List(labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error listing: %v", err)
+	}
+	if len(quotas) != 1 {
+		t.Fatalf("expected List to keep serving the stale cache before Refresh, got %+v", quotas)
+	}
+
+	cached.Refresh()
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		quotas, err := cached.List(labels.Everything())
+		if err != nil {
+			return false, err
+		}
+		return len(quotas) == 2, nil
+	}); err != nil {
+		t.Fatalf("cache never picked up the refreshed quota list: %v", err)
+	}
+}
+
+// TestCachedClusterResourceQuotaListerCoalescesRefresh verifies that firing Refresh repeatedly
+// while a refresh is already pending doesn't block the caller or panic.
+func TestCachedClusterResourceQuotaListerCoalescesRefresh(t *testing.T) {
+	fake := &fakeClusterResourceQuotaLister{}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	cached, err := NewCachedClusterResourceQuotaLister(fake, time.Hour, stopCh)
+	if err != nil {
+		t.Fatalf("unexpected error constructing cache: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			cached.Refresh()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Refresh calls blocked instead of coalescing")
+	}
+}
+
+// fakeImageStreamLister is an ImageStreamLister whose backing streams can be swapped out at
+// runtime, to drive CachedImageStreamLister's refresh behavior.
+type fakeImageStreamLister struct {
+	lock    sync.Mutex
+	streams []*imageapi.ImageStream
+}
+
+func (f *fakeImageStreamLister) setStreams(streams []*imageapi.ImageStream) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.streams = streams
+}
+
+func (f *fakeImageStreamLister) List(namespace string, selector labels.Selector) ([]*imageapi.ImageStream, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*imageapi.ImageStream{}, f.streams...), nil
+}
+
+func (f *fakeImageStreamLister) Get(namespace, name string) (*imageapi.ImageStream, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, stream := range f.streams {
+		if stream.Namespace == namespace && stream.Name == name {
+			return stream, nil
+		}
+	}
+	return nil, nil
+}
+
+// TestCachedImageStreamLister covers per-namespace List, NamespaceAll List flattening across
+// namespaces, and picking up a Refresh the same way TestCachedClusterResourceQuotaLister does.
+func TestCachedImageStreamLister(t *testing.T) {
+	fake := &fakeImageStreamLister{
+		streams: []*imageapi.ImageStream{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "a"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "b"}},
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	cached, err := NewCachedImageStreamLister(fake, time.Hour, stopCh)
+	if err != nil {
+		t.Fatalf("unexpected error constructing cache: %v", err)
+	}
+
+	streams, err := cached.List("ns1", labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error listing: %v", err)
+	}
+	if len(streams) != 1 || streams[0].Name != "a" {
+		t.Fatalf("expected only ns1's stream %q, got %+v", "a", streams)
+	}
+
+	all, err := cached.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error listing: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected NamespaceAll to flatten streams across every namespace, got %+v", all)
+	}
+
+	if _, err := cached.Get("ns1", "missing"); err == nil {
+		t.Errorf("expected a not-found error for a stream that doesn't exist")
+	}
+
+	fake.setStreams([]*imageapi.ImageStream{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "b"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "c"}},
+	})
+	cached.Refresh()
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		all, err := cached.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			return false, err
+		}
+		return len(all) == 3, nil
+	}); err != nil {
+		t.Fatalf("cache never picked up the refreshed stream list: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package shared
+
+import (
+	"reflect"
+
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	quotaapi "github.com/openshift/origin/pkg/quota/api"
+)
+
+// clusterResourceQuotaCacheKey is the informerCacheKey for ClusterResourceQuota, a cluster-scoped
+// resource: it deliberately ignores f.namespace and f.namespaceTweak (both only meaningful for
+// namespaced resources), so that ForNamespace views of the same factory share the one cluster-wide
+// informer instead of each spinning up a redundant, still-cluster-wide watch of their own.
+func clusterResourceQuotaCacheKey(tweak TweakListOptionsFunc) informerCacheKey {
+	return informerCacheKey{
+		resourceType: reflect.TypeOf(&quotaapi.ClusterResourceQuota{}),
+		namespace:    metav1.NamespaceAll,
+		filterHash:   filterHashFor(tweak),
+	}
+}
+
+// ClusterResourceQuotaInformer gives access to a shared informer and lister for
+// ClusterResourceQuotas.
+type ClusterResourceQuotaInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() ClusterResourceQuotaLister
+}
+
+type clusterResourceQuotaInformer struct {
+	*sharedInformerFactory
+}
+
+func (f *clusterResourceQuotaInformer) Informer() cache.SharedIndexInformer {
+	resource := quotaapi.Resource("clusterresourcequotas")
+	resync := f.resyncPeriodFor(resource)
+	tweak := f.tweakListOptionsFor(resource)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := clusterResourceQuotaCacheKey(tweak)
+	if informer, exists := f.informers[key]; exists {
+		return informer
+	}
+
+	var lw cache.ListerWatcher
+	if f.customListerWatchers != nil {
+		lw = f.customListerWatchers.GetListerWatcher(resource)
+	}
+	if lw == nil {
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweak != nil {
+					tweak(&options)
+				}
+				return f.originClient.ClusterResourceQuotas().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweak != nil {
+					tweak(&options)
+				}
+				return f.originClient.ClusterResourceQuotas().Watch(options)
+			},
+		}
+	}
+
+	informer := cache.NewSharedIndexInformer(
+		lw,
+		&quotaapi.ClusterResourceQuota{},
+		resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	f.informers[key] = informer
+	return informer
+}
+
+func (f *clusterResourceQuotaInformer) Lister() ClusterResourceQuotaLister {
+	return &clusterResourceQuotaLister{indexer: f.Informer().GetIndexer()}
+}
+
+// clusterResourceQuotaLister is a cache.Indexer-backed ClusterResourceQuotaLister, the uncached
+// counterpart to CachedClusterResourceQuotaLister.
+type clusterResourceQuotaLister struct {
+	indexer cache.Indexer
+}
+
+func (l *clusterResourceQuotaLister) List(selector labels.Selector) ([]*quotaapi.ClusterResourceQuota, error) {
+	ret := []*quotaapi.ClusterResourceQuota{}
+	err := cache.ListAll(l.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*quotaapi.ClusterResourceQuota))
+	})
+	return ret, err
+}
+
+func (l *clusterResourceQuotaLister) Get(name string) (*quotaapi.ClusterResourceQuota, error) {
+	obj, exists, err := l.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, kapierrors.NewNotFound(quotaapi.Resource("clusterresourcequota"), name)
+	}
+	return obj.(*quotaapi.ClusterResourceQuota), nil
+}
@@ -1,11 +1,16 @@
 package shared
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	kclientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
 	kinformers "k8s.io/kubernetes/pkg/client/informers/informers_generated/externalversions"
@@ -20,6 +25,15 @@ type InformerFactory interface {
 	// StartCore starts core informers that must initialize in order for the API server to start
 	StartCore(stopCh <-chan struct{})
 
+	// WaitForCacheSync blocks until all started informers' caches were synced or stopCh is closed.
+	// Informers created through InformerFor are not waited on and have no entry in the result.
+	// The result is keyed by informerCacheKey rather than plain reflect.Type so that two started
+	// informers of the same type but different namespace/filter (see ForNamespace) are reported
+	// independently instead of one overwriting the other.
+	WaitForCacheSync(stopCh <-chan struct{}) map[informerCacheKey]bool
+	// WaitForCoreCacheSync blocks until all started core informers' caches were synced or stopCh is closed
+	WaitForCoreCacheSync(stopCh <-chan struct{}) map[informerCacheKey]bool
+
 	ClusterPolicies() ClusterPolicyInformer
 	ClusterPolicyBindings() ClusterPolicyBindingInformer
 	Policies() PolicyInformer
@@ -34,6 +48,16 @@ type InformerFactory interface {
 
 	KubernetesInformers() kinformers.SharedInformerFactory
 	InternalKubernetesInformers() kinternalinformers.SharedInformerFactory
+
+	// ForNamespace returns an InformerFactory whose informers are scoped to list/watch only
+	// objects in ns, sharing this factory's caches for any namespace/filter combination already
+	// in use.
+	ForNamespace(ns string) InformerFactory
+
+	// InformerFor returns the SharedIndexInformer for gvr, creating it with newFunc if it doesn't
+	// already exist. This lets callers plug an arbitrary GroupVersionResource into the factory's
+	// lifecycle (Start, caching) without adding a typed method for every resource.
+	InformerFor(gvr schema.GroupVersionResource, newFunc func(cache.ListerWatcher, time.Duration) cache.SharedIndexInformer) cache.SharedIndexInformer
 }
 
 // ListerWatcherOverrides allows a caller to specify special behavior for particular ListerWatchers
@@ -50,11 +74,42 @@ func (o DefaultListerWatcherOverrides) GetListerWatcher(resource schema.GroupRes
 	return o[resource]
 }
 
+// TweakListOptionsFunc mutates a ListOptions before it is passed to the ListerWatcher, mirroring
+// upstream client-go's internalinterfaces.TweakListOptionsFunc. It lets a caller narrow what an
+// informer watches (label/field selectors) without forking the ListerWatcher itself.
+type TweakListOptionsFunc func(*metav1.ListOptions)
+
 func NewInformerFactory(
 	internalKubeInformers kinternalinformers.SharedInformerFactory,
 	kubeInformers kinformers.SharedInformerFactory,
 	kubeClient kclientset.Interface,
 	originClient oclient.Interface,
+	dynamicClient dynamic.Interface,
+	customListerWatchers ListerWatcherOverrides,
+	defaultResync time.Duration,
+) InformerFactory {
+	return NewFilteredInformerFactory(metav1.NamespaceAll, nil, internalKubeInformers, kubeInformers, kubeClient, originClient, dynamicClient, customListerWatchers, defaultResync)
+}
+
+// NewFilteredInformerFactory is like NewInformerFactory, but scopes every namespaced Origin
+// informer it creates to namespace (metav1.NamespaceAll for cluster-wide) and runs
+// tweakListOptions against the ListOptions used for each informer's initial LIST and subsequent
+// WATCH. This mirrors the namespace- and TweakListOptions-aware factories generated by client-go
+// v1.10+. Cluster-scoped resources (e.g. ClusterResourceQuota) ignore both namespace and
+// tweakListOptions, since there is no per-namespace view to scope to; ForNamespace is a no-op for
+// their informers.
+//
+// dynamicClient is used by InformerFor to build a ListerWatcher for any GroupVersionResource that
+// has no registered ListerWatcherOverride; it may be nil if the caller never intends to use
+// InformerFor without an override.
+func NewFilteredInformerFactory(
+	namespace string,
+	tweakListOptions TweakListOptionsFunc,
+	internalKubeInformers kinternalinformers.SharedInformerFactory,
+	kubeInformers kinformers.SharedInformerFactory,
+	kubeClient kclientset.Interface,
+	originClient oclient.Interface,
+	dynamicClient dynamic.Interface,
 	customListerWatchers ListerWatcherOverrides,
 	defaultResync time.Duration,
 ) InformerFactory {
@@ -63,13 +118,94 @@ func NewInformerFactory(
 		kubeInformers:         kubeInformers,
 		kubeClient:            kubeClient,
 		originClient:          originClient,
+		dynamicClient:         dynamicClient,
 		customListerWatchers:  customListerWatchers,
 		defaultResync:         defaultResync,
+		resyncPeriods:         map[schema.GroupResource]time.Duration{},
+		tweakListOptions:      map[schema.GroupResource]TweakListOptionsFunc{},
+		namespace:             namespace,
+		namespaceTweak:        tweakListOptions,
+
+		informers:            map[informerCacheKey]cache.SharedIndexInformer{},
+		coreInformers:        map[informerCacheKey]cache.SharedIndexInformer{},
+		startedInformers:     map[informerCacheKey]bool{},
+		startedCoreInformers: map[informerCacheKey]bool{},
+
+		gvrInformers:        map[gvrInformerCacheKey]cache.SharedIndexInformer{},
+		startedGVRInformers: map[gvrInformerCacheKey]bool{},
+
+		lock: &sync.Mutex{},
+	}
+}
+
+// WithResyncPeriod overrides defaultResync for the given resource's informer. It must be called
+// before the resource's Informer() is first requested, since informers are created lazily and
+// cached for the lifetime of the factory.
+func (f *sharedInformerFactory) WithResyncPeriod(resource schema.GroupResource, resync time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.resyncPeriods[resource] = resync
+}
+
+// WithTweakListOptions registers a TweakListOptionsFunc for the given resource's informer. It must
+// be called before the resource's Informer() is first requested, for the same reason as
+// WithResyncPeriod.
+func (f *sharedInformerFactory) WithTweakListOptions(resource schema.GroupResource, tweak TweakListOptionsFunc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.tweakListOptions[resource] = tweak
+}
+
+// resyncPeriodFor returns the per-resource resync override for resource, if any, falling back to
+// defaultResync.
+func (f *sharedInformerFactory) resyncPeriodFor(resource schema.GroupResource) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if resync, ok := f.resyncPeriods[resource]; ok {
+		return resync
+	}
+	return f.defaultResync
+}
+
+// tweakListOptionsFor returns the TweakListOptionsFunc registered for resource, or nil if none was
+// registered.
+func (f *sharedInformerFactory) tweakListOptionsFor(resource schema.GroupResource) TweakListOptionsFunc {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.tweakListOptions[resource]
+}
+
+// informerCacheKey identifies a cached informer by the type it lists/watches, the namespace it is
+// scoped to (metav1.NamespaceAll for cluster-wide) and a hash of the TweakListOptionsFunc applied
+// to it, so that a namespace-scoped or filtered request for a type never collides with the
+// cluster-scoped, unfiltered variant of the same type.
+type informerCacheKey struct {
+	resourceType reflect.Type
+	namespace    string
+	filterHash   string
+}
+
+func filterHashFor(tweak TweakListOptionsFunc) string {
+	if tweak == nil {
+		return ""
+	}
+	return fmt.Sprintf("%p", tweak)
+}
+
+// gvrInformerCacheKey is informerCacheKey's analogue for InformerFor, which caches by
+// GroupVersionResource rather than reflect.Type: it carries the same namespace/filter components so
+// that two ForNamespace views requesting the same GVR don't collide on the same cached informer.
+type gvrInformerCacheKey struct {
+	gvr        schema.GroupVersionResource
+	namespace  string
+	filterHash string
+}
 
-		informers:            map[reflect.Type]cache.SharedIndexInformer{},
-		coreInformers:        map[reflect.Type]cache.SharedIndexInformer{},
-		startedInformers:     map[reflect.Type]bool{},
-		startedCoreInformers: map[reflect.Type]bool{},
+func (f *sharedInformerFactory) gvrCacheKeyFor(gvr schema.GroupVersionResource) gvrInformerCacheKey {
+	return gvrInformerCacheKey{
+		gvr:        gvr,
+		namespace:  f.namespace,
+		filterHash: filterHashFor(f.namespaceTweak),
 	}
 }
 
@@ -80,12 +216,98 @@ type sharedInformerFactory struct {
 	originClient          oclient.Interface
 	customListerWatchers  ListerWatcherOverrides
 	defaultResync         time.Duration
+	resyncPeriods         map[schema.GroupResource]time.Duration
+	tweakListOptions      map[schema.GroupResource]TweakListOptionsFunc
+	namespace             string
+	namespaceTweak        TweakListOptionsFunc
+	dynamicClient         dynamic.Interface
 
-	informers            map[reflect.Type]cache.SharedIndexInformer
-	coreInformers        map[reflect.Type]cache.SharedIndexInformer
-	startedInformers     map[reflect.Type]bool
-	startedCoreInformers map[reflect.Type]bool
-	lock                 sync.Mutex
+	informers            map[informerCacheKey]cache.SharedIndexInformer
+	coreInformers        map[informerCacheKey]cache.SharedIndexInformer
+	startedInformers     map[informerCacheKey]bool
+	startedCoreInformers map[informerCacheKey]bool
+
+	gvrInformers        map[gvrInformerCacheKey]cache.SharedIndexInformer
+	startedGVRInformers map[gvrInformerCacheKey]bool
+
+	lock *sync.Mutex
+}
+
+// cacheKeyFor builds the informerCacheKey this factory's view (namespace + filter) uses for t.
+func (f *sharedInformerFactory) cacheKeyFor(t reflect.Type) informerCacheKey {
+	return informerCacheKey{
+		resourceType: t,
+		namespace:    f.namespace,
+		filterHash:   filterHashFor(f.namespaceTweak),
+	}
+}
+
+func (f *sharedInformerFactory) ForNamespace(ns string) InformerFactory {
+	scoped := *f
+	scoped.namespace = ns
+	return &scoped
+}
+
+func (f *sharedInformerFactory) InformerFor(gvr schema.GroupVersionResource, newFunc func(cache.ListerWatcher, time.Duration) cache.SharedIndexInformer) cache.SharedIndexInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := f.gvrCacheKeyFor(gvr)
+	if informer, exists := f.gvrInformers[key]; exists {
+		return informer
+	}
+
+	var lw cache.ListerWatcher
+	if f.customListerWatchers != nil {
+		lw = f.customListerWatchers.GetListerWatcher(gvr.GroupResource())
+	}
+	if lw == nil {
+		lw = f.dynamicListerWatcherFor(gvr)
+	}
+
+	resync := f.defaultResync
+	if override, ok := f.resyncPeriods[gvr.GroupResource()]; ok {
+		resync = override
+	}
+
+	informer := newFunc(lw, resync)
+	f.gvrInformers[key] = informer
+	return informer
+}
+
+// dynamicListerWatcherFor builds a ListerWatcher for gvr backed by f.dynamicClient, scoped to
+// f.namespace. Callers must hold f.lock. If the factory was constructed without a dynamic client,
+// the returned ListerWatcher fails its List/Watch calls with a descriptive error instead of
+// panicking, so a misconfigured InformerFor call surfaces as a retried watch error rather than a
+// crash.
+func (f *sharedInformerFactory) dynamicListerWatcherFor(gvr schema.GroupVersionResource) cache.ListerWatcher {
+	if f.dynamicClient == nil {
+		err := fmt.Errorf("no ListerWatcherOverride and no dynamic client configured for %s", gvr)
+		return &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return nil, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return nil, err
+			},
+		}
+	}
+
+	resourceClient := f.dynamicClient.Resource(gvr).Namespace(f.namespace)
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			if f.namespaceTweak != nil {
+				f.namespaceTweak(&options)
+			}
+			return resourceClient.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			if f.namespaceTweak != nil {
+				f.namespaceTweak(&options)
+			}
+			return resourceClient.Watch(options)
+		},
+	}
 }
 
 func (f *sharedInformerFactory) Start(stopCh <-chan struct{}) {
@@ -98,6 +320,13 @@ func (f *sharedInformerFactory) Start(stopCh <-chan struct{}) {
 			f.startedInformers[informerType] = true
 		}
 	}
+
+	for gvrKey, informer := range f.gvrInformers {
+		if !f.startedGVRInformers[gvrKey] {
+			go informer.Run(stopCh)
+			f.startedGVRInformers[gvrKey] = true
+		}
+	}
 }
 
 func (f *sharedInformerFactory) StartCore(stopCh <-chan struct{}) {
@@ -112,6 +341,48 @@ func (f *sharedInformerFactory) StartCore(stopCh <-chan struct{}) {
 	}
 }
 
+func (f *sharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[informerCacheKey]bool {
+	informers := func() map[informerCacheKey]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[informerCacheKey]cache.SharedIndexInformer{}
+		for informerKey, informer := range f.informers {
+			if f.startedInformers[informerKey] {
+				informers[informerKey] = informer
+			}
+		}
+		return informers
+	}()
+
+	res := map[informerCacheKey]bool{}
+	for informKey, informer := range informers {
+		res[informKey] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+func (f *sharedInformerFactory) WaitForCoreCacheSync(stopCh <-chan struct{}) map[informerCacheKey]bool {
+	informers := func() map[informerCacheKey]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[informerCacheKey]cache.SharedIndexInformer{}
+		for informerKey, informer := range f.coreInformers {
+			if f.startedCoreInformers[informerKey] {
+				informers[informerKey] = informer
+			}
+		}
+		return informers
+	}()
+
+	res := map[informerCacheKey]bool{}
+	for informKey, informer := range informers {
+		res[informKey] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
 func (f *sharedInformerFactory) ClusterPolicies() ClusterPolicyInformer {
 	return &clusterPolicyInformer{sharedInformerFactory: f}
 }
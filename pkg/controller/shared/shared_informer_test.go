@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestInformerFactory() *sharedInformerFactory {
+	return NewInformerFactory(nil, nil, nil, nil, nil, nil, time.Minute).(*sharedInformerFactory)
+}
+
+func newUnstructuredInformer(lw cache.ListerWatcher, resync time.Duration) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, resync, cache.Indexers{})
+}
+
+// TestInformerForScopedByNamespace guards against InformerFor handing a namespace-scoped caller
+// back another namespace's informer for the same GVR (the gvrInformers cache must be keyed by
+// namespace, not just GVR).
+func TestInformerForScopedByNamespace(t *testing.T) {
+	factory := newTestInformerFactory()
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	ns1 := factory.ForNamespace("ns1")
+	ns2 := factory.ForNamespace("ns2")
+
+	informer1 := ns1.InformerFor(gvr, newUnstructuredInformer)
+	informer1Again := ns1.InformerFor(gvr, newUnstructuredInformer)
+	informer2 := ns2.InformerFor(gvr, newUnstructuredInformer)
+
+	if informer1 != informer1Again {
+		t.Errorf("expected InformerFor to return the cached informer for the same namespace")
+	}
+	if informer1 == informer2 {
+		t.Errorf("expected InformerFor to return distinct informers for distinct namespaces, got the same instance")
+	}
+}
+
+// TestWaitForCacheSyncKeyedByNamespace guards against two started informers of the same
+// reflect.Type (but different namespace) overwriting each other's readiness in the result of
+// WaitForCacheSync.
+func TestWaitForCacheSyncKeyedByNamespace(t *testing.T) {
+	factory := newTestInformerFactory()
+
+	keyA := informerCacheKey{resourceType: nil, namespace: "ns1", filterHash: ""}
+	keyB := informerCacheKey{resourceType: nil, namespace: "ns2", filterHash: ""}
+
+	informerA := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, time.Minute, cache.Indexers{})
+	informerB := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, time.Minute, cache.Indexers{})
+
+	factory.informers[keyA] = informerA
+	factory.informers[keyB] = informerB
+	factory.startedInformers[keyA] = true
+	factory.startedInformers[keyB] = true
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+
+	res := factory.WaitForCacheSync(stopCh)
+	if _, ok := res[keyA]; !ok {
+		t.Errorf("expected a readiness entry for %+v", keyA)
+	}
+	if _, ok := res[keyB]; !ok {
+		t.Errorf("expected a readiness entry for %+v", keyB)
+	}
+	if len(res) != 2 {
+		t.Errorf("expected 2 distinct readiness entries, got %d: %+v", len(res), res)
+	}
+}